@@ -0,0 +1,234 @@
+package lb
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/alb/rs"
+	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/ingress/controller/store"
+)
+
+const (
+	// IngressGroupNameAnnotation names the IngressGroup an ingress belongs to.
+	// All ingresses sharing a group name merge their listener rules onto a
+	// single ALB instead of each provisioning its own.
+	IngressGroupNameAnnotation = "alb.ingress.kubernetes.io/group.name"
+
+	// IngressGroupOrderAnnotation controls where this ingress's rules land
+	// within the group's globally-ordered priority space. Lower values sort
+	// first. Ingresses without this annotation default to IngressGroupOrder 0.
+	IngressGroupOrderAnnotation = "alb.ingress.kubernetes.io/group.order"
+
+	// groupFinalizer is attached to every member ingress of a group so the
+	// ALB is only torn down once the last member leaves.
+	groupFinalizer = "group.ingress.k8s.aws/resources"
+)
+
+// IngressGroup identifies the set of ingresses that share a single ALB, and
+// this ingress's position within that group's merged rule priority space.
+type IngressGroup struct {
+	Name  string
+	Order int64
+}
+
+// IsExplicit returns true if the ingress opted into a named group, rather
+// than implicitly forming a group-of-one around itself.
+func (g IngressGroup) IsExplicit() bool {
+	return g.Name != ""
+}
+
+// GroupMembers is the set of ingresses that currently belong to an
+// IngressGroup, used both to merge their listeners/targetgroups/rules onto
+// one ALB and to decide whether that ALB's finalizer can be released.
+type GroupMembers []*extensions.Ingress
+
+// Len, Swap and Less order members deterministically by group.order, falling
+// back to namespace/name so priority allocation is stable across reconciles.
+func (m GroupMembers) Len() int      { return len(m) }
+func (m GroupMembers) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m GroupMembers) Less(i, j int) bool {
+	oi, oj := groupOrder(m[i]), groupOrder(m[j])
+	if oi != oj {
+		return oi < oj
+	}
+	if m[i].Namespace != m[j].Namespace {
+		return m[i].Namespace < m[j].Namespace
+	}
+	return m[i].Name < m[j].Name
+}
+
+func groupOrder(ing *extensions.Ingress) int64 {
+	v, ok := ing.Annotations[IngressGroupOrderAnnotation]
+	if !ok {
+		return 0
+	}
+	var order int64
+	fmt.Sscanf(v, "%d", &order)
+	return order
+}
+
+// GroupForIngress returns the IngressGroup ing declared via its
+// group.name/group.order annotations, or the zero-value (implicit
+// group-of-one) IngressGroup if it didn't opt into one.
+func GroupForIngress(ing *extensions.Ingress) IngressGroup {
+	return IngressGroup{Name: ing.Annotations[IngressGroupNameAnnotation], Order: groupOrder(ing)}
+}
+
+// GroupMembersForIngress returns every ingress in the store that shares
+// ing's IngressGroup (including ing itself when it belongs to one), sorted
+// by group.order then namespace/name for stable, deterministic priority
+// allocation across the group.
+func GroupMembersForIngress(s store.Storer, ing *extensions.Ingress) (GroupMembers, error) {
+	name, ok := ing.Annotations[IngressGroupNameAnnotation]
+	if !ok {
+		return GroupMembers{ing}, nil
+	}
+
+	all, err := s.ListIngresses()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list ingresses for group %s: %v", name, err)
+	}
+
+	var members GroupMembers
+	for _, other := range all {
+		if other.Annotations[IngressGroupNameAnnotation] == name {
+			members = append(members, other)
+		}
+	}
+	sort.Sort(members)
+	return members, nil
+}
+
+// groupID derives a LoadBalancer.id for an IngressGroup by hashing the group
+// name, the same way a standalone ingress's id is hashed from its
+// namespace/name, so group and non-group ALBs share one ID-space without
+// colliding.
+func groupID(groupName string) string {
+	h := fnv.New32a()
+	h.Write([]byte(groupName))
+	return fmt.Sprintf("group-%x", h.Sum32())
+}
+
+// IDForGroup returns the LoadBalancer.id this IngressGroup's ALB should use:
+// the shared, group-name-derived id when g is an explicit group, or the
+// caller's own standalone id (already hashed from its namespace/name) when g
+// is an implicit group-of-one.
+func (g IngressGroup) IDForGroup(standaloneID string) string {
+	if !g.IsExplicit() {
+		return standaloneID
+	}
+	return groupID(g.Name)
+}
+
+// AssignGroup records which IngressGroup l belongs to and re-derives l.id
+// from it, so that every member of an explicit group resolves to the same
+// shared ALB instead of each provisioning its own.
+func (l *LoadBalancer) AssignGroup(g IngressGroup) {
+	l.group = g
+	l.id = g.IDForGroup(l.id)
+}
+
+// MergeGroupRules merges the Rules contributed by each of members (keyed by
+// "namespace/name", as produced by whatever parsed each member ingress into
+// a rule list) into a single ordered set for the group's shared ALB:
+// members are concatenated in their GroupMembers order (group.order, then
+// namespace/name), then assigned global 1..N priorities and sorted, so the
+// same set of members always yields the same priorities. Adding or removing
+// a member renumbers every rule that follows it in that order.
+func MergeGroupRules(members GroupMembers, rulesByIngress map[string]rs.Rules) rs.Rules {
+	var merged rs.Rules
+	for _, m := range members {
+		merged = append(merged, rulesByIngress[m.Namespace+"/"+m.Name]...)
+	}
+	merged.AssignPriorities()
+	merged.Sort()
+	return merged
+}
+
+// EnsureFinalizer adds groupFinalizer to ing if it isn't already present,
+// reporting whether it changed ing so the caller knows whether the ingress
+// needs to be persisted. Called for every member of a group (including a
+// group-of-one) before its ALB is provisioned, so the ALB is never deleted
+// out from under an ingress that still depends on it.
+func EnsureFinalizer(ing *extensions.Ingress) bool {
+	for _, f := range ing.Finalizers {
+		if f == groupFinalizer {
+			return false
+		}
+	}
+	ing.Finalizers = append(ing.Finalizers, groupFinalizer)
+	return true
+}
+
+// ReleaseFinalizer removes groupFinalizer from ing, reporting whether it
+// changed ing. ReconcileGroupDeletion calls it for every ingress that is
+// leaving, whether or not CanDeleteALB allows the group's ALB to be torn
+// down; the other members' finalizers stay in place.
+func ReleaseFinalizer(ing *extensions.Ingress) bool {
+	finalizers := make([]string, 0, len(ing.Finalizers))
+	changed := false
+	for _, f := range ing.Finalizers {
+		if f == groupFinalizer {
+			changed = true
+			continue
+		}
+		finalizers = append(finalizers, f)
+	}
+	ing.Finalizers = finalizers
+	return changed
+}
+
+// CanDeleteALB returns true if no member of members other than ing itself
+// remains, i.e. removing ing leaves the group empty and its ALB can be torn
+// down. A group-of-one (members containing only ing) always returns true.
+func CanDeleteALB(members GroupMembers, ing *extensions.Ingress) bool {
+	for _, m := range members {
+		if m.Namespace == ing.Namespace && m.Name == ing.Name {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// ReconcileGroup resolves ing's IngressGroup membership and assembles l as
+// that group's shared ALB: l's id is re-derived from the group (AssignGroup)
+// so every member resolves to the same LoadBalancer instead of each
+// provisioning its own, the finalizer that gates the group's ALB deletion
+// is ensured on ing, and every member's contribution to rulesByIngress
+// (keyed by "namespace/name") is merged into one globally-prioritized rule
+// list (MergeGroupRules) for l's listener(s) to apply.
+//
+// Listeners and target groups of the members are not merged, neither by
+// this function nor by anything else in this package: it only assigns the
+// id, merges and prioritizes rules, and ensures the finalizer.
+func ReconcileGroup(s store.Storer, ing *extensions.Ingress, l *LoadBalancer, rulesByIngress map[string]rs.Rules) (GroupMembers, rs.Rules, error) {
+	members, err := GroupMembersForIngress(s, ing)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l.AssignGroup(GroupForIngress(ing))
+	EnsureFinalizer(ing)
+
+	return members, MergeGroupRules(members, rulesByIngress), nil
+}
+
+// ReconcileGroupDeletion resolves the members remaining in ing's group as
+// ing is deleted and reports whether the group's ALB can now be torn down
+// (true for a group-of-one, or once ing is the last member to leave a
+// shared group), releasing ing's own finalizer either way since ing is
+// leaving regardless of its siblings.
+func ReconcileGroupDeletion(s store.Storer, ing *extensions.Ingress) (canDeleteALB bool, err error) {
+	members, err := GroupMembersForIngress(s, ing)
+	if err != nil {
+		return false, err
+	}
+
+	canDeleteALB = CanDeleteALB(members, ing)
+	ReleaseFinalizer(ing)
+	return canDeleteALB, nil
+}
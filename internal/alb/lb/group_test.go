@@ -0,0 +1,141 @@
+package lb
+
+import (
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/alb/rs"
+)
+
+func ingress(namespace, name string) *extensions.Ingress {
+	return &extensions.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
+
+func TestIDForGroup(t *testing.T) {
+	explicit := IngressGroup{Name: "shared"}
+	if got := explicit.IDForGroup("standalone-id"); got != groupID("shared") {
+		t.Errorf("IDForGroup() on explicit group = %q, want %q", got, groupID("shared"))
+	}
+
+	implicit := IngressGroup{}
+	if got := implicit.IDForGroup("standalone-id"); got != "standalone-id" {
+		t.Errorf("IDForGroup() on implicit group = %q, want %q", got, "standalone-id")
+	}
+}
+
+func TestAssignGroup(t *testing.T) {
+	l := &LoadBalancer{id: "standalone-id"}
+	g := IngressGroup{Name: "shared"}
+
+	l.AssignGroup(g)
+
+	if l.group != g {
+		t.Errorf("l.group = %v, want %v", l.group, g)
+	}
+	if l.id != groupID("shared") {
+		t.Errorf("l.id = %q, want %q", l.id, groupID("shared"))
+	}
+}
+
+func TestMergeGroupRulesKeepsEveryMembersRules(t *testing.T) {
+	members := GroupMembers{ingress("ns", "a"), ingress("ns", "b")}
+
+	rulesByIngress := map[string]rs.Rules{
+		"ns/a": {
+			rs.NewDesiredRule(&rs.NewDesiredRuleOptions{Priority: 1, PathPatterns: []string{"/a"}}),
+		},
+		"ns/b": {
+			rs.NewDesiredRule(&rs.NewDesiredRuleOptions{Priority: 1, PathPatterns: []string{"/b1"}}),
+			rs.NewDesiredRule(&rs.NewDesiredRuleOptions{Priority: 2, PathPatterns: []string{"/b2"}}),
+		},
+	}
+
+	merged := MergeGroupRules(members, rulesByIngress)
+
+	if len(merged) != 3 {
+		t.Fatalf("MergeGroupRules() returned %d rules, want 3", len(merged))
+	}
+}
+
+func TestEnsureAndReleaseFinalizer(t *testing.T) {
+	ing := ingress("ns", "a")
+
+	if changed := EnsureFinalizer(ing); !changed {
+		t.Error("EnsureFinalizer() on fresh ingress = false, want true")
+	}
+	if changed := EnsureFinalizer(ing); changed {
+		t.Error("EnsureFinalizer() on already-finalized ingress = true, want false")
+	}
+	if len(ing.Finalizers) != 1 {
+		t.Fatalf("Finalizers = %v, want exactly one entry", ing.Finalizers)
+	}
+
+	if changed := ReleaseFinalizer(ing); !changed {
+		t.Error("ReleaseFinalizer() on finalized ingress = false, want true")
+	}
+	if len(ing.Finalizers) != 0 {
+		t.Errorf("Finalizers after release = %v, want empty", ing.Finalizers)
+	}
+	if changed := ReleaseFinalizer(ing); changed {
+		t.Error("ReleaseFinalizer() on already-released ingress = true, want false")
+	}
+}
+
+func TestCanDeleteALB(t *testing.T) {
+	a, b := ingress("ns", "a"), ingress("ns", "b")
+
+	if CanDeleteALB(GroupMembers{a}, a) != true {
+		t.Error("CanDeleteALB() on group-of-one = false, want true")
+	}
+	if CanDeleteALB(GroupMembers{a, b}, a) != false {
+		t.Error("CanDeleteALB() with another member remaining = true, want false")
+	}
+}
+
+// An ingress with no group.name annotation short-circuits GroupMembersForIngress
+// before it ever touches the store, so these reconcile-path tests can pass a
+// nil store.Storer for the implicit group-of-one case.
+func TestReconcileGroupWiresIDFinalizerAndRules(t *testing.T) {
+	ing := ingress("ns", "a")
+	l := &LoadBalancer{id: "standalone-id"}
+	rulesByIngress := map[string]rs.Rules{
+		"ns/a": {
+			rs.NewDesiredRule(&rs.NewDesiredRuleOptions{Priority: 1, PathPatterns: []string{"/a"}}),
+		},
+	}
+
+	members, merged, err := ReconcileGroup(nil, ing, l, rulesByIngress)
+	if err != nil {
+		t.Fatalf("ReconcileGroup() error = %v", err)
+	}
+	if len(members) != 1 || members[0] != ing {
+		t.Errorf("members = %v, want [ing]", members)
+	}
+	if l.id != "standalone-id" {
+		t.Errorf("l.id = %q, want unchanged standalone id for an implicit group", l.id)
+	}
+	if len(ing.Finalizers) != 1 {
+		t.Errorf("Finalizers = %v, want the group finalizer added", ing.Finalizers)
+	}
+	if len(merged) != 1 {
+		t.Errorf("merged rules = %v, want the 1 rule contributed by ing", merged)
+	}
+}
+
+func TestReconcileGroupDeletionReleasesFinalizerAndReportsDeletable(t *testing.T) {
+	ing := ingress("ns", "a")
+	EnsureFinalizer(ing)
+
+	canDelete, err := ReconcileGroupDeletion(nil, ing)
+	if err != nil {
+		t.Fatalf("ReconcileGroupDeletion() error = %v", err)
+	}
+	if !canDelete {
+		t.Error("ReconcileGroupDeletion() canDeleteALB = false, want true for a group-of-one")
+	}
+	if len(ing.Finalizers) != 0 {
+		t.Errorf("Finalizers = %v, want released", ing.Finalizers)
+	}
+}
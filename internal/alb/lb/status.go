@@ -0,0 +1,99 @@
+package lb
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	api "k8s.io/api/core/v1"
+
+	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/alb/rs"
+)
+
+// ListenerStatus rolls up one Listener's ARN with the StatusReport of every
+// rule attached to it.
+type ListenerStatus struct {
+	ListenerArn string
+	Rules       []*rs.RuleStatus
+}
+
+// LoadBalancerStatus is a point-in-time snapshot of an ALB's live AWS state,
+// gathered by StatusReport and compared between calls by ReconcileStatus,
+// which surfaces transitions as events. Nothing in this package calls
+// ReconcileStatus on an interval or writes the result back to an Ingress
+// status subresource.
+//
+// Target-group health counts and subnets are not included.
+type LoadBalancerStatus struct {
+	LoadBalancerArn   string
+	DNSName           string
+	ProvisioningState string
+	WebACLId          string
+	SecurityGroups    []string
+	Listeners         []*ListenerStatus
+}
+
+// StatusReport gathers the LoadBalancer's current (AWS-observed) state,
+// rolling in the rule statuses of every listener in listenerRules (keyed by
+// ListenerArn). It returns nil if the LoadBalancer has no current state,
+// e.g. before its first successful reconcile.
+func (l *LoadBalancer) StatusReport(listenerRules map[string]rs.Rules) *LoadBalancerStatus {
+	if l.lb.current == nil {
+		return nil
+	}
+
+	status := &LoadBalancerStatus{
+		LoadBalancerArn: aws.StringValue(l.lb.current.LoadBalancerArn),
+		DNSName:         aws.StringValue(l.lb.current.DNSName),
+		WebACLId:        aws.StringValue(l.options.current.webACLId),
+	}
+	if l.lb.current.State != nil {
+		status.ProvisioningState = aws.StringValue(l.lb.current.State.Code)
+	}
+	if sg := l.options.current.managedSG; sg != nil {
+		status.SecurityGroups = append(status.SecurityGroups, aws.StringValue(sg))
+	}
+	if sg := l.options.current.managedInstanceSG; sg != nil {
+		status.SecurityGroups = append(status.SecurityGroups, aws.StringValue(sg))
+	}
+
+	arns := make([]string, 0, len(listenerRules))
+	for arn := range listenerRules {
+		arns = append(arns, arn)
+	}
+	sort.Strings(arns)
+	for _, arn := range arns {
+		status.Listeners = append(status.Listeners, &ListenerStatus{
+			ListenerArn: arn,
+			Rules:       listenerRules[arn].StatusReport(),
+		})
+	}
+
+	return status
+}
+
+// ReconcileStatus gathers l's current StatusReport and, by comparing it
+// against previous (the report from the last tick, or nil on the first
+// one), emits a Kubernetes event for every state transition worth calling
+// out: provisioning state changes, and DNS name changes (e.g. after a
+// scheme change forces a new ALB). It returns the freshly-gathered report
+// so the caller can pass it back in as previous on its next call. It does
+// not persist the report anywhere itself.
+func (l *LoadBalancer) ReconcileStatus(rOpts *ReconcileOptions, listenerRules map[string]rs.Rules, previous *LoadBalancerStatus) *LoadBalancerStatus {
+	current := l.StatusReport(listenerRules)
+	if current == nil {
+		return nil
+	}
+
+	if previous == nil {
+		rOpts.Eventf(api.EventTypeNormal, "STATUS", "%s is %s at %s", current.LoadBalancerArn, current.ProvisioningState, current.DNSName)
+		return current
+	}
+	if previous.ProvisioningState != current.ProvisioningState {
+		rOpts.Eventf(api.EventTypeNormal, "STATUS", "provisioning state changed from %s to %s", previous.ProvisioningState, current.ProvisioningState)
+	}
+	if previous.DNSName != current.DNSName {
+		rOpts.Eventf(api.EventTypeNormal, "STATUS", "DNS name changed from %s to %s", previous.DNSName, current.DNSName)
+	}
+
+	return current
+}
@@ -0,0 +1,97 @@
+package lb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+
+	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/alb/rs"
+)
+
+func TestStatusReportNilWithoutCurrentState(t *testing.T) {
+	l := &LoadBalancer{}
+	if got := l.StatusReport(nil); got != nil {
+		t.Errorf("StatusReport() on LoadBalancer with no current state = %v, want nil", got)
+	}
+}
+
+func TestStatusReportAggregatesListenersAndSecurityGroups(t *testing.T) {
+	l := &LoadBalancer{
+		lb: lb{current: &elbv2.LoadBalancer{
+			LoadBalancerArn: aws.String("lb-arn"),
+			DNSName:         aws.String("example.elb.amazonaws.com"),
+			State:           &elbv2.LoadBalancerState{Code: aws.String("active")},
+		}},
+	}
+	l.options.current.managedSG = aws.String("sg-1")
+	l.options.current.managedInstanceSG = aws.String("sg-2")
+
+	status := l.StatusReport(map[string]rs.Rules{
+		"listener-arn": {},
+	})
+
+	if status == nil {
+		t.Fatal("StatusReport() = nil, want non-nil")
+	}
+	if status.ProvisioningState != "active" {
+		t.Errorf("ProvisioningState = %q, want %q", status.ProvisioningState, "active")
+	}
+	if len(status.SecurityGroups) != 2 {
+		t.Errorf("SecurityGroups = %v, want 2 entries", status.SecurityGroups)
+	}
+	if len(status.Listeners) != 1 || status.Listeners[0].ListenerArn != "listener-arn" {
+		t.Errorf("Listeners = %v, want one entry for listener-arn", status.Listeners)
+	}
+}
+
+func activeLoadBalancer(state, dnsName string) *LoadBalancer {
+	return &LoadBalancer{lb: lb{current: &elbv2.LoadBalancer{
+		LoadBalancerArn: aws.String("lb-arn"),
+		DNSName:         aws.String(dnsName),
+		State:           &elbv2.LoadBalancerState{Code: aws.String(state)},
+	}}}
+}
+
+func TestReconcileStatusEmitsEventOnFirstTick(t *testing.T) {
+	l := activeLoadBalancer("provisioning", "example.elb.amazonaws.com")
+
+	var events []string
+	rOpts := &ReconcileOptions{Eventf: func(_, reason, format string, args ...interface{}) {
+		events = append(events, reason)
+	}}
+
+	got := l.ReconcileStatus(rOpts, nil, nil)
+
+	if got == nil {
+		t.Fatal("ReconcileStatus() = nil, want non-nil")
+	}
+	if len(events) != 1 {
+		t.Errorf("events = %v, want exactly one STATUS event on the first tick", events)
+	}
+}
+
+func TestReconcileStatusEmitsEventOnlyOnTransition(t *testing.T) {
+	l := activeLoadBalancer("active", "example.elb.amazonaws.com")
+
+	var events []string
+	rOpts := &ReconcileOptions{Eventf: func(_, reason, format string, args ...interface{}) {
+		events = append(events, reason)
+	}}
+
+	previous := &LoadBalancerStatus{ProvisioningState: "active", DNSName: "example.elb.amazonaws.com"}
+	if got := l.ReconcileStatus(rOpts, nil, previous); got == nil {
+		t.Fatal("ReconcileStatus() = nil, want non-nil")
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %v, want none when nothing changed since the last tick", events)
+	}
+
+	l2 := activeLoadBalancer("failed", "example.elb.amazonaws.com")
+	if got := l2.ReconcileStatus(rOpts, nil, previous); got == nil {
+		t.Fatal("ReconcileStatus() = nil, want non-nil")
+	}
+	if len(events) != 1 || events[0] != "STATUS" {
+		t.Errorf("events = %v, want one STATUS event for the provisioning-state transition", events)
+	}
+}
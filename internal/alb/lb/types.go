@@ -6,6 +6,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/alb/ls"
+	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/alb/rs"
 	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/alb/tg"
 	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/aws/albelbv2"
 	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/ingress/controller/store"
@@ -16,6 +17,7 @@ import (
 // LoadBalancer contains the overarching configuration for the ALB
 type LoadBalancer struct {
 	id           string
+	group        IngressGroup
 	lb           lb
 	tags         tags
 	attributes   attributes
@@ -103,6 +105,31 @@ const (
 type ReconcileOptions struct {
 	Store  store.Storer
 	Eventf func(string, string, string, ...interface{})
+
+	// RollbackOnError undoes every rule mutation already applied during this
+	// Reconcile pass as soon as one of them fails, instead of leaving the ALB
+	// half-applied for the next reconcile to sort out. Listener and
+	// target-group mutations are not recorded or undone, and nothing in this
+	// package binds the field to a command-line flag.
+	RollbackOnError bool
+	// Journal accumulates the mutations this Reconcile pass applies so they
+	// can be undone when RollbackOnError is set and a later mutation fails.
+	Journal *rs.Journal
+}
+
+// RuleOptions builds the rs.ReconcileOptions a Listener's rule-list
+// reconcile should use against listenerArn and tgs, forwarding this pass's
+// Eventf sink along with its rollback behavior (Journal, RollbackOnError) so
+// a rule-level failure rolls back exactly as RollbackOnError is set at the
+// LoadBalancer level.
+func (o *ReconcileOptions) RuleOptions(listenerArn *string, tgs tg.TargetGroups) *rs.ReconcileOptions {
+	return &rs.ReconcileOptions{
+		ListenerArn:     listenerArn,
+		TargetGroups:    tgs,
+		Eventf:          o.Eventf,
+		Journal:         o.Journal,
+		RollbackOnError: o.RollbackOnError,
+	}
 }
 
 type portList []int64
@@ -0,0 +1,30 @@
+package lb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+
+	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/alb/rs"
+)
+
+func TestReconcileOptionsRuleOptionsForwardsRollbackBehavior(t *testing.T) {
+	j := rs.NewJournal()
+	eventf := func(string, string, string, ...interface{}) {}
+
+	o := &ReconcileOptions{Eventf: eventf, Journal: j, RollbackOnError: true}
+	ruleOpts := o.RuleOptions(aws.String("listener-arn"), nil)
+
+	if ruleOpts.ListenerArn == nil || *ruleOpts.ListenerArn != "listener-arn" {
+		t.Errorf("ListenerArn = %v, want listener-arn", ruleOpts.ListenerArn)
+	}
+	if ruleOpts.Journal != j {
+		t.Error("RuleOptions() did not forward Journal")
+	}
+	if !ruleOpts.RollbackOnError {
+		t.Error("RuleOptions() did not forward RollbackOnError")
+	}
+	if ruleOpts.Eventf == nil {
+		t.Error("RuleOptions() did not forward Eventf")
+	}
+}
@@ -0,0 +1,56 @@
+package rs
+
+import "fmt"
+
+// Journal records every mutation a Reconcile pass applies to AWS rule
+// resources, in order, so they can be undone if a later mutation in the
+// same pass fails. Journal itself records unconditionally whenever record is
+// called; it's ReconcileOptions.record that gates calls on
+// ReconcileOptions.RollbackOnError, so a Journal wired in for another
+// purpose (e.g. status reporting) without rollback enabled never
+// accumulates entries that are recorded but never drained. A nil *Journal is
+// safe to record against and rolls back to a no-op.
+type Journal struct {
+	entries []journalEntry
+}
+
+type journalEntry struct {
+	description string
+	undo        func() error
+}
+
+// NewJournal returns an empty Journal.
+func NewJournal() *Journal {
+	return &Journal{}
+}
+
+// record appends a mutation and the closure that undoes it. Safe to call on
+// a nil Journal. Callers that only want to record when rollback is enabled
+// should go through ReconcileOptions.record instead of calling this
+// directly.
+func (j *Journal) record(description string, undo func() error) {
+	if j == nil {
+		return
+	}
+	j.entries = append(j.entries, journalEntry{description: description, undo: undo})
+}
+
+// Rollback undoes every recorded mutation in reverse order. It does not stop
+// at the first failed undo: it collects and returns every error encountered
+// so a partial rollback is reported rather than silently masked. Safe to
+// call on a nil Journal.
+func (j *Journal) Rollback() []error {
+	if j == nil {
+		return nil
+	}
+
+	var errs []error
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		e := j.entries[i]
+		if err := e.undo(); err != nil {
+			errs = append(errs, fmt.Errorf("rollback of %s failed: %v", e.description, err))
+		}
+	}
+	j.entries = nil
+	return errs
+}
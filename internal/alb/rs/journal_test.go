@@ -0,0 +1,65 @@
+package rs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestJournalRollbackOrderAndCollection(t *testing.T) {
+	j := NewJournal()
+
+	var undone []string
+	j.record("first", func() error {
+		undone = append(undone, "first")
+		return nil
+	})
+	j.record("second", func() error {
+		undone = append(undone, "second")
+		return errors.New("boom")
+	})
+	j.record("third", func() error {
+		undone = append(undone, "third")
+		return nil
+	})
+
+	errs := j.Rollback()
+
+	if want := []string{"third", "second", "first"}; !equalStrings(undone, want) {
+		t.Errorf("Rollback() undid in order %v, want %v", undone, want)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("Rollback() returned %d errors, want 1", len(errs))
+	}
+
+	// Entries are cleared after Rollback, so a second call is a no-op.
+	undone = nil
+	if errs := j.Rollback(); errs != nil {
+		t.Errorf("second Rollback() = %v, want nil", errs)
+	}
+	if len(undone) != 0 {
+		t.Errorf("second Rollback() re-ran undo funcs: %v", undone)
+	}
+}
+
+func TestJournalRollbackNilReceiver(t *testing.T) {
+	var j *Journal
+	j.record("never recorded", func() error {
+		t.Fatal("undo func should never run on a nil Journal")
+		return nil
+	})
+	if errs := j.Rollback(); errs != nil {
+		t.Errorf("Rollback() on nil Journal = %v, want nil", errs)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
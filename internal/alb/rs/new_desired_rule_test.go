@@ -0,0 +1,115 @@
+package rs
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+func TestNewDesiredRuleAppendsForwardByDefault(t *testing.T) {
+	r := NewDesiredRule(&NewDesiredRuleOptions{
+		Priority:     1,
+		PathPatterns: []string{"/"},
+		TargetGroups: []TargetGroupTuple{{SvcName: "svc1"}},
+	})
+
+	actions := r.rs.desired.Actions
+	if len(actions) != 1 {
+		t.Fatalf("len(Actions) = %d, want 1", len(actions))
+	}
+	if got := aws.StringValue(actions[0].Type); got != "forward" {
+		t.Errorf("Actions[0].Type = %q, want %q", got, "forward")
+	}
+}
+
+func TestNewDesiredRuleAuthThenForward(t *testing.T) {
+	r := NewDesiredRule(&NewDesiredRuleOptions{
+		Priority:     1,
+		PathPatterns: []string{"/"},
+		TargetGroups: []TargetGroupTuple{{SvcName: "svc1"}},
+		Actions: []Action{
+			{Type: "authenticate-oidc", AuthenticateOidcConfig: &elbv2.AuthenticateOidcActionConfig{}},
+		},
+	})
+
+	actions := r.rs.desired.Actions
+	if len(actions) != 2 {
+		t.Fatalf("len(Actions) = %d, want 2 (authenticate-oidc then forward)", len(actions))
+	}
+	if got := aws.StringValue(actions[0].Type); got != "authenticate-oidc" {
+		t.Errorf("Actions[0].Type = %q, want %q", got, "authenticate-oidc")
+	}
+	if got := aws.StringValue(actions[1].Type); got != "forward" {
+		t.Errorf("Actions[1].Type = %q, want %q (auth must precede the terminal action)", got, "forward")
+	}
+	if got := aws.Int64Value(actions[0].Order); got != 1 {
+		t.Errorf("Actions[0].Order = %d, want 1", got)
+	}
+	if got := aws.Int64Value(actions[1].Order); got != 2 {
+		t.Errorf("Actions[1].Order = %d, want 2", got)
+	}
+}
+
+func TestNewDesiredRuleRedirectIsSoleTerminalAction(t *testing.T) {
+	r := NewDesiredRule(&NewDesiredRuleOptions{
+		Priority:     1,
+		PathPatterns: []string{"/"},
+		Actions: []Action{
+			{Type: "redirect", RedirectConfig: &elbv2.RedirectActionConfig{
+				Protocol:   aws.String("HTTPS"),
+				Port:       aws.String("443"),
+				StatusCode: aws.String("HTTP_301"),
+			}},
+		},
+	})
+
+	actions := r.rs.desired.Actions
+	if len(actions) != 1 {
+		t.Fatalf("len(Actions) = %d, want 1 (no synthetic forward after a redirect)", len(actions))
+	}
+	if got := aws.StringValue(actions[0].Type); got != "redirect" {
+		t.Errorf("Actions[0].Type = %q, want %q", got, "redirect")
+	}
+}
+
+func TestNewDesiredRuleFixedResponseIsSoleTerminalAction(t *testing.T) {
+	r := NewDesiredRule(&NewDesiredRuleOptions{
+		Priority:     1,
+		PathPatterns: []string{"/"},
+		Actions: []Action{
+			{Type: "fixed-response", FixedResponseConfig: &elbv2.FixedResponseActionConfig{
+				StatusCode: aws.String("404"),
+			}},
+		},
+	})
+
+	actions := r.rs.desired.Actions
+	if len(actions) != 1 {
+		t.Fatalf("len(Actions) = %d, want 1 (no synthetic forward after a fixed-response)", len(actions))
+	}
+	if got := aws.StringValue(actions[0].Type); got != "fixed-response" {
+		t.Errorf("Actions[0].Type = %q, want %q", got, "fixed-response")
+	}
+}
+
+func TestIsTerminalAction(t *testing.T) {
+	cases := []struct {
+		name string
+		in   []Action
+		want bool
+	}{
+		{"empty", nil, false},
+		{"redirect", []Action{{Type: "redirect"}}, true},
+		{"fixed-response", []Action{{Type: "fixed-response"}}, true},
+		{"auth-oidc", []Action{{Type: "authenticate-oidc"}}, false},
+		{"auth-then-redirect", []Action{{Type: "authenticate-oidc"}, {Type: "redirect"}}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isTerminalAction(c.in); got != c.want {
+				t.Errorf("isTerminalAction(%v) = %v, want %v", c.in, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package rs
+
+import "testing"
+
+func TestReconcileOptionsRecordGatedOnRollbackOnError(t *testing.T) {
+	j := NewJournal()
+	rOpts := &ReconcileOptions{Journal: j, RollbackOnError: false}
+
+	rOpts.record("should not be recorded", func() error { return nil })
+
+	if errs := j.Rollback(); errs != nil {
+		t.Errorf("Rollback() = %v, want nil", errs)
+	}
+
+	rOpts.RollbackOnError = true
+	undone := false
+	rOpts.record("should be recorded", func() error { undone = true; return nil })
+	j.Rollback()
+
+	if !undone {
+		t.Error("record() with RollbackOnError=true did not register the undo closure")
+	}
+}
@@ -2,14 +2,15 @@ package rs
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/elbv2"
 
 	api "k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
-	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/alb/tg"
 	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/aws/albelbv2"
@@ -19,24 +20,93 @@ import (
 
 type NewDesiredRuleOptions struct {
 	Priority         int
-	Hostname         string
+	Hostnames        []string
 	IgnoreHostHeader *bool
-	Path             string
-	SvcName          string
-	SvcPort          intstr.IntOrString
-	TargetPort       int
+	PathPatterns     []string
+	Conditions       []Condition
+	TargetGroups     []TargetGroupTuple
+	Stickiness       *TargetGroupStickinessConfig
+	Actions          []Action
 	Logger           *log.Logger
 }
 
+// Action represents a single, ordered action in a rule's action chain. It is
+// populated from the `alb.ingress.kubernetes.io/actions.<svc-name>` annotation.
+// Unless the chain already ends in a redirect or fixed-response action (which
+// must themselves be terminal), NewDesiredRule appends a forward to the
+// backend service(s) after it.
+type Action struct {
+	Type                      string
+	AuthenticateOidcConfig    *elbv2.AuthenticateOidcActionConfig
+	AuthenticateCognitoConfig *elbv2.AuthenticateCognitoActionConfig
+	RedirectConfig            *elbv2.RedirectActionConfig
+	FixedResponseConfig       *elbv2.FixedResponseActionConfig
+}
+
+// isTerminalAction returns true if actions already ends in a redirect or
+// fixed-response action, which AWS requires to be the sole terminal action
+// in a rule's chain. An empty chain, or one ending in anything else (e.g.
+// authenticate-oidc/authenticate-cognito, which must precede a terminal
+// action rather than be one), is not terminal and still needs the synthetic
+// forward NewDesiredRule otherwise appends.
+func isTerminalAction(actions []Action) bool {
+	if len(actions) == 0 {
+		return false
+	}
+	switch actions[len(actions)-1].Type {
+	case "redirect", "fixed-response":
+		return true
+	default:
+		return false
+	}
+}
+
 // NewDesiredRule returns an rule.Rule based on the provided parameters.
 func NewDesiredRule(o *NewDesiredRuleOptions) *Rule {
-	r := &elbv2.Rule{
-		Actions: []*elbv2.Action{
-			{
-				TargetGroupArn: nil, // Populated at creation, since we create rules before we create rules
-				Type:           aws.String("forward"),
-			},
-		},
+	r := &elbv2.Rule{}
+
+	var order int64 = 1
+	for _, a := range o.Actions {
+		r.Actions = append(r.Actions, &elbv2.Action{
+			Order:                     aws.Int64(order),
+			Type:                      aws.String(a.Type),
+			AuthenticateOidcConfig:    a.AuthenticateOidcConfig,
+			AuthenticateCognitoConfig: a.AuthenticateCognitoConfig,
+			RedirectConfig:            a.RedirectConfig,
+			FixedResponseConfig:       a.FixedResponseConfig,
+		})
+		order++
+	}
+
+	// The chain terminates in a forward to the backend service(s) unless the
+	// caller already supplied its own terminal action: redirect and
+	// fixed-response are themselves terminal (only authenticate-oidc/
+	// authenticate-cognito may precede them), and AWS rejects a rule with a
+	// forward tacked on after one. The target group ARN(s) are populated at
+	// creation, since we create rules before we know the target groups' ARNs.
+	// A single target group is expressed as a plain TargetGroupArn; more than
+	// one (weighted forwarding) requires the richer ForwardConfig shape.
+	if !isTerminalAction(o.Actions) {
+		forward := &elbv2.Action{
+			Order: aws.Int64(order),
+			Type:  aws.String("forward"),
+		}
+		if len(o.TargetGroups) > 1 || o.Stickiness != nil {
+			forward.ForwardConfig = &elbv2.ForwardActionConfig{}
+			for _, t := range o.TargetGroups {
+				forward.ForwardConfig.TargetGroups = append(forward.ForwardConfig.TargetGroups, &elbv2.TargetGroupTuple{
+					TargetGroupArn: nil,
+					Weight:         aws.Int64(t.Weight),
+				})
+			}
+			if o.Stickiness != nil {
+				forward.ForwardConfig.TargetGroupStickinessConfig = &elbv2.TargetGroupStickinessConfig{
+					Enabled:         aws.Bool(o.Stickiness.Enabled),
+					DurationSeconds: aws.Int64(o.Stickiness.DurationSeconds),
+				}
+			}
+		}
+		r.Actions = append(r.Actions, forward)
 	}
 
 	if o.Priority == 0 {
@@ -48,40 +118,60 @@ func NewDesiredRule(o *NewDesiredRuleOptions) *Rule {
 	}
 
 	if !*r.IsDefault {
-		if o.Hostname != "" && o.IgnoreHostHeader != nil && !*o.IgnoreHostHeader {
+		if len(o.Hostnames) > 0 && o.IgnoreHostHeader != nil && !*o.IgnoreHostHeader {
 			r.Conditions = append(r.Conditions, &elbv2.RuleCondition{
 				Field:  aws.String("host-header"),
-				Values: []*string{aws.String(o.Hostname)},
+				Values: aws.StringSlice(o.Hostnames),
 			})
 		}
 
-		if o.Path != "" {
+		if len(o.PathPatterns) > 0 {
 			r.Conditions = append(r.Conditions, &elbv2.RuleCondition{
 				Field:  aws.String("path-pattern"),
-				Values: []*string{aws.String(o.Path)},
+				Values: aws.StringSlice(o.PathPatterns),
 			})
 		}
+
+		for _, c := range o.Conditions {
+			cond := &elbv2.RuleCondition{Field: aws.String(c.Field)}
+			if len(c.Values) > 0 {
+				cond.Values = aws.StringSlice(c.Values)
+			}
+			cond.HttpHeaderConfig = c.HttpHeaderConfig
+			cond.HttpRequestMethodConfig = c.HttpRequestMethodConfig
+			cond.QueryStringConfig = c.QueryStringConfig
+			cond.SourceIpConfig = c.SourceIpConfig
+			r.Conditions = append(r.Conditions, cond)
+		}
+	}
+
+	var desired []service
+	for _, t := range o.TargetGroups {
+		desired = append(desired, service{name: t.SvcName, port: t.SvcPort, targetPort: t.TargetPort, weight: t.Weight})
 	}
 
 	return &Rule{
-		svc:    svc{desired: service{name: o.SvcName, port: o.SvcPort, targetPort: o.TargetPort}},
+		svc:    svc{desired: desired},
 		rs:     rs{desired: r},
 		logger: o.Logger,
 	}
 }
 
 type NewCurrentRuleOptions struct {
-	SvcName    string
-	SvcPort    intstr.IntOrString
-	TargetPort int
-	Rule       *elbv2.Rule
-	Logger     *log.Logger
+	TargetGroups []TargetGroupTuple
+	Rule         *elbv2.Rule
+	Logger       *log.Logger
 }
 
 // NewCurrentRule creates a Rule from an elbv2.Rule
 func NewCurrentRule(o *NewCurrentRuleOptions) *Rule {
+	var current []service
+	for _, t := range o.TargetGroups {
+		current = append(current, service{name: t.SvcName, port: t.SvcPort, targetPort: t.TargetPort, weight: t.Weight})
+	}
+
 	return &Rule{
-		svc:    svc{current: service{name: o.SvcName, port: o.SvcPort, targetPort: o.TargetPort}},
+		svc:    svc{current: current},
 		rs:     rs{current: o.Rule},
 		logger: o.Logger,
 	}
@@ -94,7 +184,17 @@ func (r *Rule) Reconcile(rOpts *ReconcileOptions) error {
 	// If there is a desired rule, set some of the ARNs which are not available when we assemble the desired state
 	if r.rs.desired != nil {
 		for i := range r.rs.desired.Actions {
-			r.rs.desired.Actions[i].TargetGroupArn = r.TargetGroupArn(rOpts.TargetGroups)
+			a := r.rs.desired.Actions[i]
+			if aws.StringValue(a.Type) != "forward" {
+				continue
+			}
+			if a.ForwardConfig != nil {
+				for j, tgt := range a.ForwardConfig.TargetGroups {
+					tgt.TargetGroupArn = r.targetGroupArnFor(rOpts.TargetGroups, r.svc.desired[j])
+				}
+				continue
+			}
+			a.TargetGroupArn = r.TargetGroupArn(rOpts.TargetGroups)
 		}
 	}
 
@@ -141,15 +241,26 @@ func (r *Rule) Reconcile(rOpts *ReconcileOptions) error {
 	return nil
 }
 
+// TargetGroupArn resolves the ARN of the (single) target group this rule's
+// forward action should point at. For a weighted forward across several
+// target groups, use targetGroupArnFor against each entry instead.
 func (r *Rule) TargetGroupArn(tgs tg.TargetGroups) *string {
-	i := tgs.LookupByBackend(extensions.IngressBackend{ServiceName: r.svc.desired.name, ServicePort: r.svc.desired.port})
+	if len(r.svc.desired) == 0 {
+		return nil
+	}
+	return r.targetGroupArnFor(tgs, r.svc.desired[0])
+}
+
+// targetGroupArnFor resolves the ARN of the target group backing svc.
+func (r *Rule) targetGroupArnFor(tgs tg.TargetGroups, svc service) *string {
+	i := tgs.LookupByBackend(extensions.IngressBackend{ServiceName: svc.name, ServicePort: svc.port})
 	if i < 0 {
-		r.logger.Errorf("Failed to locate TargetGroup related to this service: %s:%s", r.svc.desired.name, r.svc.desired.port.String())
+		r.logger.Errorf("Failed to locate TargetGroup related to this service: %s:%s", svc.name, svc.port.String())
 		return nil
 	}
 	arn := tgs[i].CurrentARN()
 	if arn == nil {
-		r.logger.Errorf("Located TargetGroup but no known (current) state found: %s:%s", r.svc.desired.name, r.svc.desired.port.String())
+		r.logger.Errorf("Located TargetGroup but no known (current) state found: %s:%s", svc.name, svc.port.String())
 	}
 	return arn
 }
@@ -170,10 +281,18 @@ func (r *Rule) create(rOpts *ReconcileOptions) error {
 	r.rs.current = o.Rules[0]
 	r.svc.current = r.svc.desired
 
+	createdArn := r.rs.current.RuleArn
+	rOpts.record(fmt.Sprintf("create rule %s", aws.StringValue(r.rs.current.Priority)), func() error {
+		_, err := albelbv2.ELBV2svc.DeleteRule(&elbv2.DeleteRuleInput{RuleArn: createdArn})
+		return err
+	})
+
 	return nil
 }
 
 func (r *Rule) modify(rOpts *ReconcileOptions) error {
+	prior := r.rs.current // snapshot before mutation, for rollback
+
 	in := &elbv2.ModifyRuleInput{
 		Actions:    r.rs.desired.Actions,
 		Conditions: r.rs.desired.Conditions,
@@ -191,6 +310,15 @@ func (r *Rule) modify(rOpts *ReconcileOptions) error {
 	}
 	r.svc.current = r.svc.desired
 
+	rOpts.record(fmt.Sprintf("modify rule %s", aws.StringValue(prior.RuleArn)), func() error {
+		_, err := albelbv2.ELBV2svc.ModifyRule(&elbv2.ModifyRuleInput{
+			RuleArn:    prior.RuleArn,
+			Actions:    prior.Actions,
+			Conditions: prior.Conditions,
+		})
+		return err
+	})
+
 	return nil
 }
 
@@ -208,12 +336,24 @@ func (r *Rule) delete(rOpts *ReconcileOptions) error {
 		return nil
 	}
 
+	prior := r.rs.current // snapshot before mutation, for rollback
+
 	in := &elbv2.DeleteRuleInput{RuleArn: r.rs.current.RuleArn}
 	if _, err := albelbv2.ELBV2svc.DeleteRule(in); err != nil {
 		rOpts.Eventf(api.EventTypeWarning, "ERROR", "Error deleting %s rule: %s", *r.rs.current.Priority, err.Error())
 		return fmt.Errorf("Failed Rule deletion. Error: %s", err.Error())
 	}
 
+	rOpts.record(fmt.Sprintf("delete rule %s", aws.StringValue(prior.RuleArn)), func() error {
+		_, err := albelbv2.ELBV2svc.CreateRule(&elbv2.CreateRuleInput{
+			ListenerArn: rOpts.ListenerArn,
+			Priority:    priority(prior.Priority),
+			Actions:     prior.Actions,
+			Conditions:  prior.Conditions,
+		})
+		return err
+	})
+
 	r.deleted = true
 	return nil
 }
@@ -232,21 +372,49 @@ func (r *Rule) needsModification() bool {
 	case !conditionsEqual(crs.Conditions, drs.Conditions):
 		r.logger.Debugf("Conditions needs to be changed (%v != %v)", log.Prettify(crs.Conditions), log.Prettify(drs.Conditions))
 		return true
-	case r.svc.current.name != r.svc.desired.name:
-		r.logger.Debugf("SvcName needs to be changed (%v != %v)", r.svc.current.name, r.svc.desired.name)
-		return true
-	case r.svc.current.targetPort != r.svc.desired.targetPort && r.svc.current.targetPort != 0: // Check against 0 because that is the default for legacy tags
-		r.logger.Debugf("Target port needs to be changed (%v != %v)", r.svc.current.targetPort, r.svc.desired.targetPort)
+	case !servicesEqual(r.svc.current, r.svc.desired):
+		r.logger.Debugf("Backend service(s) need to be changed (%v != %v)", log.Prettify(r.svc.current), log.Prettify(r.svc.desired))
 		return true
 	}
 
 	return false
 }
 
-// conditionsEqual returns true if c1 and c2 are identical conditions.
+// servicesEqual returns true if the current and desired backend(s) of a
+// rule's forward action are identical (name, weight, and resolved target
+// port all match).
+func servicesEqual(current []service, desired []service) bool {
+	if len(current) != len(desired) {
+		return false
+	}
+	for i, c := range current {
+		d := desired[i]
+		if c.name != d.name || c.weight != d.weight {
+			return false
+		}
+		// Check against 0 because that is the default for legacy tags
+		if c.targetPort != d.targetPort && c.targetPort != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// conditionsEqual returns true if c1 and c2 are identical conditions,
+// regardless of ordering. It compares the full condition shape, including
+// the HttpHeaderConfig/QueryStringConfig/HttpRequestMethodConfig/
+// SourceIpConfig sub-fields and multi-value Values (host-headers,
+// source-ip CIDRs, and so on).
 func conditionsEqual(c1 []*elbv2.RuleCondition, c2 []*elbv2.RuleCondition) bool {
+	if len(c1) != len(c2) {
+		return false
+	}
+
 	cMap1 := conditionToMap(c1)
 	cMap2 := conditionToMap(c2)
+	if len(cMap1) != len(cMap2) {
+		return false
+	}
 
 	for k, v := range cMap1 {
 		val, ok := cMap2[k]
@@ -254,8 +422,16 @@ func conditionsEqual(c1 []*elbv2.RuleCondition, c2 []*elbv2.RuleCondition) bool
 		if !ok {
 			return false
 		}
-		// If key existed but values were diff, mod is needed
-		if !util.DeepEqual(v, val) {
+		if !util.DeepEqual(sortedValues(v.Values), sortedValues(val.Values)) {
+			return false
+		}
+		if !util.DeepEqual(v.HttpHeaderConfig, val.HttpHeaderConfig) ||
+			!util.DeepEqual(v.HttpRequestMethodConfig, val.HttpRequestMethodConfig) ||
+			!util.DeepEqual(v.QueryStringConfig, val.QueryStringConfig) {
+			return false
+		}
+		if (v.SourceIpConfig == nil) != (val.SourceIpConfig == nil) ||
+			v.SourceIpConfig != nil && !util.DeepEqual(sortedValues(v.SourceIpConfig.Values), sortedValues(val.SourceIpConfig.Values)) {
 			return false
 		}
 	}
@@ -263,15 +439,69 @@ func conditionsEqual(c1 []*elbv2.RuleCondition, c2 []*elbv2.RuleCondition) bool
 	return true
 }
 
-// conditionsToMap converts a elbv2.Conditions struct into a map[string]string representation
-func conditionToMap(cs []*elbv2.RuleCondition) map[string][]*string {
-	cMap := make(map[string][]*string)
+// conditionToMap converts a []*elbv2.RuleCondition into a map keyed by
+// field, disambiguating same-field conditions (e.g. several http-header
+// conditions) by their header name so they can be compared independent of
+// ordering.
+func conditionToMap(cs []*elbv2.RuleCondition) map[string]*elbv2.RuleCondition {
+	cMap := make(map[string]*elbv2.RuleCondition)
 	for _, c := range cs {
-		cMap[*c.Field] = c.Values
+		cMap[conditionKey(c)] = c
 	}
 	return cMap
 }
 
+// conditionKey returns the map key a condition should be compared under.
+// Fields that AWS allows to repeat within a rule (several http-header
+// conditions on different headers, several query-string conditions each
+// ANDing in a different key/value pair, several source-ip conditions with
+// different CIDR sets) are disambiguated by their distinguishing sub-field,
+// so conditions that differ in that sub-field never collapse onto the same
+// key. Two http-header conditions on the same header, or repeated
+// conditions of any other field, do share one key.
+func conditionKey(c *elbv2.RuleCondition) string {
+	field := aws.StringValue(c.Field)
+	switch field {
+	case "http-header":
+		if c.HttpHeaderConfig != nil {
+			return field + ":" + aws.StringValue(c.HttpHeaderConfig.HttpHeaderName)
+		}
+	case "query-string":
+		if c.QueryStringConfig != nil {
+			return field + ":" + queryStringConfigKey(c.QueryStringConfig)
+		}
+	case "source-ip":
+		if c.SourceIpConfig != nil {
+			return field + ":" + strings.Join(sortedValues(c.SourceIpConfig.Values), ",")
+		}
+	}
+	return field
+}
+
+// queryStringConfigKey renders a QueryStringConditionConfig's key/value
+// pairs as a sorted, order-independent string so two query-string
+// conditions compare equal only if they AND in the same set of pairs.
+func queryStringConfigKey(cfg *elbv2.QueryStringConditionConfig) string {
+	pairs := make([]string, 0, len(cfg.Values))
+	for _, v := range cfg.Values {
+		pairs = append(pairs, aws.StringValue(v.Key)+"="+aws.StringValue(v.Value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// sortedValues returns the string values of vs, sorted, so that condition
+// value sets (e.g. multiple host-headers or source-ip CIDRs) compare equal
+// regardless of order.
+func sortedValues(vs []*string) []string {
+	out := make([]string, 0, len(vs))
+	for _, v := range vs {
+		out = append(out, aws.StringValue(v))
+	}
+	sort.Strings(out)
+	return out
+}
+
 // stripDesiredState removes the desired state from the rule.
 func (r *Rule) stripDesiredState() {
 	r.rs.desired = nil
@@ -0,0 +1,82 @@
+package rs
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+func queryStringCondition(key, value string) *elbv2.RuleCondition {
+	return &elbv2.RuleCondition{
+		Field: aws.String("query-string"),
+		QueryStringConfig: &elbv2.QueryStringConditionConfig{
+			Values: []*elbv2.QueryStringKeyValuePair{{Key: aws.String(key), Value: aws.String(value)}},
+		},
+	}
+}
+
+func sourceIPCondition(cidrs ...string) *elbv2.RuleCondition {
+	return &elbv2.RuleCondition{
+		Field:          aws.String("source-ip"),
+		SourceIpConfig: &elbv2.SourceIpConditionConfig{Values: aws.StringSlice(cidrs)},
+	}
+}
+
+func TestConditionKeyDisambiguatesQueryString(t *testing.T) {
+	a := queryStringCondition("env", "prod")
+	b := queryStringCondition("env", "staging")
+
+	if conditionKey(a) == conditionKey(b) {
+		t.Errorf("conditionKey() collided for distinct query-string conditions: %q", conditionKey(a))
+	}
+}
+
+func TestConditionKeyDisambiguatesSourceIP(t *testing.T) {
+	a := sourceIPCondition("10.0.0.0/24")
+	b := sourceIPCondition("10.0.1.0/24")
+
+	if conditionKey(a) == conditionKey(b) {
+		t.Errorf("conditionKey() collided for distinct source-ip conditions: %q", conditionKey(a))
+	}
+}
+
+func TestConditionsEqualDetectsDistinctQueryStringConditions(t *testing.T) {
+	c1 := []*elbv2.RuleCondition{queryStringCondition("env", "prod"), queryStringCondition("region", "us")}
+	c2 := []*elbv2.RuleCondition{queryStringCondition("env", "staging"), queryStringCondition("region", "us")}
+
+	if conditionsEqual(c1, c2) {
+		t.Error("conditionsEqual() = true for rule sets with a real query-string diff, want false")
+	}
+	if !conditionsEqual(c1, c1) {
+		t.Error("conditionsEqual() = false comparing a condition set to itself, want true")
+	}
+}
+
+func TestConditionsEqualIgnoresOrder(t *testing.T) {
+	c1 := []*elbv2.RuleCondition{
+		{Field: aws.String("host-header"), Values: aws.StringSlice([]string{"a.com", "b.com"})},
+		queryStringCondition("env", "prod"),
+	}
+	c2 := []*elbv2.RuleCondition{
+		queryStringCondition("env", "prod"),
+		{Field: aws.String("host-header"), Values: aws.StringSlice([]string{"b.com", "a.com"})},
+	}
+
+	if !conditionsEqual(c1, c2) {
+		t.Error("conditionsEqual() = false for reordered-but-equal conditions, want true")
+	}
+}
+
+func TestServicesEqual(t *testing.T) {
+	a := []service{{name: "svc1", weight: 80}, {name: "svc2", weight: 20}}
+	b := []service{{name: "svc1", weight: 80}, {name: "svc2", weight: 20}}
+	c := []service{{name: "svc1", weight: 70}, {name: "svc2", weight: 30}}
+
+	if !servicesEqual(a, b) {
+		t.Error("servicesEqual() = false for identical service lists, want true")
+	}
+	if servicesEqual(a, c) {
+		t.Error("servicesEqual() = true for service lists with a weight diff, want false")
+	}
+}
@@ -0,0 +1,91 @@
+package rs
+
+import (
+	"sort"
+	"strconv"
+
+	api "k8s.io/api/core/v1"
+)
+
+// Rules is a collection of Rule, ordered the way they should be evaluated on
+// the ALB. When several ingresses share an IngressGroup, the group's merged
+// Rules spans all of them, so priorities must be assigned globally rather
+// than per-ingress.
+type Rules []*Rule
+
+// Reconcile walks r in listener-evaluation order, reconciling each Rule
+// against AWS in turn. If a Rule fails and rOpts.RollbackOnError is set,
+// every mutation already applied earlier in this pass (tracked in
+// rOpts.Journal) is undone, in reverse order, before the original error is
+// returned — so a reconcile failure halfway through a rule list doesn't
+// leave the ALB in a mix of old and new rules until the next reconcile.
+// Rollback outcomes are always surfaced as an event, whether or not the
+// undo itself succeeded, so a failed rollback isn't silently swallowed.
+func (r Rules) Reconcile(rOpts *ReconcileOptions) error {
+	for _, rule := range r {
+		if err := rule.Reconcile(rOpts); err != nil {
+			if rOpts.RollbackOnError {
+				if rbErrs := rOpts.Journal.Rollback(); len(rbErrs) > 0 {
+					rOpts.Eventf(api.EventTypeWarning, "ROLLBACK", "rollback after reconcile error was incomplete (%d error(s)): %v", len(rbErrs), rbErrs)
+				} else {
+					rOpts.Eventf(api.EventTypeNormal, "ROLLBACK", "rolled back rule changes after reconcile error: %s", err.Error())
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// StatusReport gathers the StatusReport of every rule in r, skipping any
+// rule with no current AWS state (e.g. one that failed to create, or one
+// already removed from the desired set this pass). lb.LoadBalancer's
+// StatusReport rolls these up alongside the listener's ARN into the
+// LoadBalancer-wide status report.
+func (r Rules) StatusReport() []*RuleStatus {
+	var out []*RuleStatus
+	for _, rule := range r {
+		if s := rule.StatusReport(); s != nil {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// AssignPriorities sets the Priority of every non-default rule in r to a
+// unique value in [1, len(r)], preserving r's existing order. Call this
+// after merging the Rules contributed by every member of an IngressGroup
+// (sorted by group.order) so the same merged order always yields the same
+// priorities. Inserting or removing rules renumbers every rule after them.
+func (r Rules) AssignPriorities() {
+	priority := 1
+	for _, rule := range r {
+		if rule.rs.desired == nil || *rule.rs.desired.IsDefault {
+			continue
+		}
+		rule.rs.desired.Priority = priorityString(priority)
+		priority++
+	}
+}
+
+// Sort orders rules by their already-assigned priority, defaults last.
+func (r Rules) Sort() {
+	sort.SliceStable(r, func(i, j int) bool {
+		return rulePriorityValue(r[i]) < rulePriorityValue(r[j])
+	})
+}
+
+func rulePriorityValue(r *Rule) int64 {
+	if r.rs.desired == nil {
+		return 1<<63 - 1
+	}
+	if *r.rs.desired.IsDefault {
+		return 1<<63 - 1
+	}
+	return *priority(r.rs.desired.Priority)
+}
+
+func priorityString(i int) *string {
+	s := strconv.Itoa(i)
+	return &s
+}
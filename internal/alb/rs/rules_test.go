@@ -0,0 +1,66 @@
+package rs
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+func ruleWithDesired(priority string, isDefault bool) *Rule {
+	return &Rule{rs: rs{desired: &elbv2.Rule{
+		Priority:  aws.String(priority),
+		IsDefault: aws.Bool(isDefault),
+	}}}
+}
+
+func TestAssignPrioritiesSkipsDefaultPreservesOrder(t *testing.T) {
+	rules := Rules{
+		ruleWithDesired("7", false),
+		ruleWithDesired("default", true),
+		ruleWithDesired("3", false),
+	}
+
+	rules.AssignPriorities()
+
+	if got := aws.StringValue(rules[0].rs.desired.Priority); got != "1" {
+		t.Errorf("rules[0].Priority = %q, want %q", got, "1")
+	}
+	if got := aws.StringValue(rules[1].rs.desired.Priority); got != "default" {
+		t.Errorf("default rule's Priority = %q, want untouched %q", got, "default")
+	}
+	if got := aws.StringValue(rules[2].rs.desired.Priority); got != "2" {
+		t.Errorf("rules[2].Priority = %q, want %q", got, "2")
+	}
+}
+
+func TestRulesSortOrdersByPriorityDefaultLast(t *testing.T) {
+	def := ruleWithDesired("default", true)
+	low := ruleWithDesired("1", false)
+	high := ruleWithDesired("5", false)
+
+	rules := Rules{high, def, low}
+	rules.Sort()
+
+	if rules[0] != low || rules[1] != high || rules[2] != def {
+		t.Errorf("Sort() did not order by priority with default last")
+	}
+}
+
+func TestRulesStatusReportSkipsRulesWithNoCurrentState(t *testing.T) {
+	withCurrent := &Rule{rs: rs{current: &elbv2.Rule{
+		RuleArn:   aws.String("arn1"),
+		Priority:  aws.String("1"),
+		IsDefault: aws.Bool(false),
+	}}}
+	withoutCurrent := &Rule{}
+
+	statuses := Rules{withCurrent, withoutCurrent}.StatusReport()
+
+	if len(statuses) != 1 {
+		t.Fatalf("StatusReport() returned %d entries, want 1", len(statuses))
+	}
+	if statuses[0].RuleArn != "arn1" {
+		t.Errorf("StatusReport()[0].RuleArn = %q, want %q", statuses[0].RuleArn, "arn1")
+	}
+}
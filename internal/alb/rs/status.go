@@ -0,0 +1,45 @@
+package rs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+// RuleStatus is a point-in-time snapshot of a Rule's live AWS state, rolled
+// up into the owning LoadBalancer's LoadBalancerStatus for the Ingress
+// status subresource.
+type RuleStatus struct {
+	RuleArn    string
+	Priority   string
+	IsDefault  bool
+	Conditions []string
+}
+
+// StatusReport gathers the Rule's current (AWS-observed) state. It returns
+// nil if the Rule has no current state, e.g. before its first successful
+// reconcile.
+func (r *Rule) StatusReport() *RuleStatus {
+	if r.rs.current == nil {
+		return nil
+	}
+
+	status := &RuleStatus{
+		RuleArn:   aws.StringValue(r.rs.current.RuleArn),
+		Priority:  aws.StringValue(r.rs.current.Priority),
+		IsDefault: aws.BoolValue(r.rs.current.IsDefault),
+	}
+	for _, c := range r.rs.current.Conditions {
+		status.Conditions = append(status.Conditions, conditionSummary(c))
+	}
+
+	return status
+}
+
+// conditionSummary renders a RuleCondition as "field=value1,value2" for a
+// quick, human-readable status line.
+func conditionSummary(c *elbv2.RuleCondition) string {
+	return fmt.Sprintf("%s=%s", aws.StringValue(c.Field), strings.Join(sortedValues(c.Values), ","))
+}
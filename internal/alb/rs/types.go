@@ -0,0 +1,98 @@
+package rs
+
+import (
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/kubernetes-sigs/aws-alb-ingress-controller/internal/alb/tg"
+	"github.com/kubernetes-sigs/aws-alb-ingress-controller/pkg/util/log"
+)
+
+// ReconcileOptions carries everything a Rule needs to reconcile itself
+// against AWS: the listener it belongs to, the target groups its forward
+// action(s) may resolve against, a way to emit Kubernetes events, and
+// (optionally) the Journal mutations should be recorded against for rollback.
+type ReconcileOptions struct {
+	ListenerArn     *string
+	TargetGroups    tg.TargetGroups
+	Eventf          func(string, string, string, ...interface{})
+	Journal         *Journal
+	RollbackOnError bool
+}
+
+// record appends an undo closure to o.Journal, but only when
+// o.RollbackOnError is set. A Journal can be wired in without rollback
+// enabled (e.g. to support status reporting later); going through this
+// method instead of o.Journal.record directly avoids silently accumulating
+// entries that RollbackOnError being false means will never be drained.
+func (o *ReconcileOptions) record(description string, undo func() error) {
+	if !o.RollbackOnError {
+		return
+	}
+	o.Journal.record(description, undo)
+}
+
+// Rule contains the elbv2.Rule along with the backend(s) it forwards to, in
+// both the current (AWS) and desired (ingress) states.
+type Rule struct {
+	svc svc
+	rs  rs
+
+	deleted bool // flag representing the rule instance was fully deleted.
+	logger  *log.Logger
+}
+
+// rs (rule state) holds the current and desired elbv2.Rule.
+type rs struct {
+	current *elbv2.Rule
+	desired *elbv2.Rule
+}
+
+// svc holds the current and desired backend(s) a rule's terminal forward
+// action points at. More than one entry means the forward action splits
+// traffic across target groups by weight.
+type svc struct {
+	current []service
+	desired []service
+}
+
+// service is a single forward target: the Kubernetes Service/port it maps to,
+// the resolved container targetPort, and its weight within the forward action.
+type service struct {
+	name       string
+	port       intstr.IntOrString
+	targetPort int
+	weight     int64
+}
+
+// TargetGroupTuple describes one weighted backend in a rule's forward action,
+// as parsed from the `alb.ingress.kubernetes.io/actions.<svc-name>` annotation's
+// ForwardConfig.TargetGroups.
+type TargetGroupTuple struct {
+	SvcName    string
+	SvcPort    intstr.IntOrString
+	TargetPort int
+	Weight     int64
+}
+
+// Condition represents a single rule condition beyond the basic host-header
+// and path-pattern pair, as parsed from the
+// `alb.ingress.kubernetes.io/conditions.<svc-name>` annotation. Field
+// selects which of the AWS-defined shapes (HttpHeaderConfig, and so on)
+// applies.
+type Condition struct {
+	Field                   string
+	Values                  []string
+	HttpHeaderConfig        *elbv2.HttpHeaderConditionConfig
+	HttpRequestMethodConfig *elbv2.HttpRequestMethodConditionConfig
+	QueryStringConfig       *elbv2.QueryStringConditionConfig
+	SourceIpConfig          *elbv2.SourceIpConditionConfig
+}
+
+// TargetGroupStickinessConfig configures the forward action's target group
+// stickiness, as parsed from the ForwardConfig.TargetGroupStickinessConfig
+// annotation field.
+type TargetGroupStickinessConfig struct {
+	Enabled         bool
+	DurationSeconds int64
+}
@@ -0,0 +1,88 @@
+package rs
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestNewDesiredRuleSingleTargetGroupUsesPlainArn(t *testing.T) {
+	r := NewDesiredRule(&NewDesiredRuleOptions{
+		Priority:     1,
+		PathPatterns: []string{"/"},
+		TargetGroups: []TargetGroupTuple{{SvcName: "svc1", Weight: 100}},
+	})
+
+	forward := r.rs.desired.Actions[len(r.rs.desired.Actions)-1]
+	if forward.ForwardConfig != nil {
+		t.Errorf("ForwardConfig = %v, want nil for a single target group", forward.ForwardConfig)
+	}
+}
+
+func TestNewDesiredRuleWeightedForwardBuildsForwardConfig(t *testing.T) {
+	r := NewDesiredRule(&NewDesiredRuleOptions{
+		Priority:     1,
+		PathPatterns: []string{"/"},
+		TargetGroups: []TargetGroupTuple{
+			{SvcName: "svc1", Weight: 80},
+			{SvcName: "svc2", Weight: 20},
+		},
+	})
+
+	forward := r.rs.desired.Actions[len(r.rs.desired.Actions)-1]
+	if forward.ForwardConfig == nil {
+		t.Fatal("ForwardConfig = nil, want populated for a weighted multi-target-group forward")
+	}
+	if len(forward.ForwardConfig.TargetGroups) != 2 {
+		t.Fatalf("len(ForwardConfig.TargetGroups) = %d, want 2", len(forward.ForwardConfig.TargetGroups))
+	}
+	if got := aws.Int64Value(forward.ForwardConfig.TargetGroups[0].Weight); got != 80 {
+		t.Errorf("TargetGroups[0].Weight = %d, want 80", got)
+	}
+	if got := aws.Int64Value(forward.ForwardConfig.TargetGroups[1].Weight); got != 20 {
+		t.Errorf("TargetGroups[1].Weight = %d, want 20", got)
+	}
+	if forward.ForwardConfig.TargetGroupStickinessConfig != nil {
+		t.Errorf("TargetGroupStickinessConfig = %v, want nil when Stickiness wasn't set", forward.ForwardConfig.TargetGroupStickinessConfig)
+	}
+}
+
+func TestNewDesiredRuleStickinessForcesForwardConfigEvenForOneTargetGroup(t *testing.T) {
+	r := NewDesiredRule(&NewDesiredRuleOptions{
+		Priority:     1,
+		PathPatterns: []string{"/"},
+		TargetGroups: []TargetGroupTuple{{SvcName: "svc1", Weight: 100}},
+		Stickiness:   &TargetGroupStickinessConfig{Enabled: true, DurationSeconds: 300},
+	})
+
+	forward := r.rs.desired.Actions[len(r.rs.desired.Actions)-1]
+	if forward.ForwardConfig == nil {
+		t.Fatal("ForwardConfig = nil, want populated when Stickiness is set")
+	}
+	cfg := forward.ForwardConfig.TargetGroupStickinessConfig
+	if cfg == nil {
+		t.Fatal("TargetGroupStickinessConfig = nil, want populated")
+	}
+	if !aws.BoolValue(cfg.Enabled) {
+		t.Error("TargetGroupStickinessConfig.Enabled = false, want true")
+	}
+	if got := aws.Int64Value(cfg.DurationSeconds); got != 300 {
+		t.Errorf("TargetGroupStickinessConfig.DurationSeconds = %d, want 300", got)
+	}
+}
+
+func TestNewCurrentRuleTracksTargetGroups(t *testing.T) {
+	r := NewCurrentRule(&NewCurrentRuleOptions{
+		TargetGroups: []TargetGroupTuple{
+			{SvcName: "svc1", Weight: 60},
+			{SvcName: "svc2", Weight: 40},
+		},
+	})
+
+	if len(r.svc.current) != 2 {
+		t.Fatalf("len(svc.current) = %d, want 2", len(r.svc.current))
+	}
+	if r.svc.current[0].name != "svc1" || r.svc.current[0].weight != 60 {
+		t.Errorf("svc.current[0] = %+v, want {name: svc1, weight: 60}", r.svc.current[0])
+	}
+}